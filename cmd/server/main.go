@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/auth"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/config"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/controllers/person"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/db"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/middleware"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/rbac"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/replication"
+)
+
+func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to the YAML config file")
+	flag.Parse()
+
+	configManager, err := config.NewManager(*configPath, flag.Args())
+
+	if err != nil {
+		panic(err)
+	}
+
+	if err := configManager.Watch(); err != nil {
+		log.Printf("config: could not watch %s for changes: %v", *configPath, err)
+	}
+
+	defer configManager.Close()
+
+	cfg := configManager.Get()
+
+	if err := auth.Configure(cfg.JWTSecrets, cfg.JWTSigningKid); err != nil {
+		panic(err)
+	}
+
+	log.Println("Creating the tenant connection pool...")
+	pool := db.NewTenantPool(
+		cfg.DatabaseDSN,
+		cfg.PoolMaxOpenConns,
+		time.Duration(cfg.PoolIdleTTLSeconds)*time.Second,
+		cfg.AutoMigrate,
+	)
+	pool.RegisterModels(&person.Person{}, &auth.User{}, &rbac.Role{}, &rbac.RolePermission{}, &rbac.UserRole{})
+	pool.RegisterMigrationHook(func(tenant string, tenantDB *gorm.DB) {
+		if err := rbac.SeedAdminRole(tenantDB); err != nil {
+			log.Printf("rbac: could not seed admin role for tenant %s: %v", tenant, err)
+		}
+	})
+
+	configManager.Subscribe(func(cfg *config.Config) {
+		if err := auth.Configure(cfg.JWTSecrets, cfg.JWTSigningKid); err != nil {
+			log.Printf("config: could not apply reloaded JWT secrets: %v", err)
+		}
+
+		pool.Resize(cfg.PoolMaxOpenConns)
+		pool.SetAutoMigrate(cfg.AutoMigrate)
+	})
+
+	authService := auth.NewService(pool)
+	authorize := middleware.Authorize(pool)
+
+	// withPermission chains tenant authorization with a resource-level
+	// permission check, so a valid JWT alone is no longer enough to call a handler
+	withPermission := func(permission string, h http.HandlerFunc) http.Handler {
+		return authorize(middleware.RequirePermission(permission)(h))
+	}
+
+	replicationScheduler, err := replication.NewScheduler(pool)
+
+	if err != nil {
+		panic(err)
+	}
+
+	replicationHandlers := replication.NewHandlers(replicationScheduler)
+	configHandlers := config.NewHandlers(configManager)
+	authorizeAdmin := func(next http.Handler) http.Handler {
+		return middleware.AuthorizeSystem(pool)(middleware.RequireRole("admin")(next))
+	}
+
+	log.Println("Success! Waiting for requests...")
+
+	router := mux.NewRouter()
+
+	router.HandleFunc("/register", authService.Register).Methods("POST")
+	router.HandleFunc("/login", authService.Login).Methods("POST")
+	router.HandleFunc("/refresh", authService.Refresh).Methods("POST")
+
+	router.Handle("/people", withPermission("person:read", person.List)).Methods("GET")
+	router.Handle("/person/{id}", withPermission("person:read", person.Get)).Methods("GET")
+	router.Handle("/person/{id}", withPermission("person:write", person.Create)).Methods("POST")
+	router.Handle("/person/{id}", withPermission("person:delete", person.Delete)).Methods("DELETE")
+	router.Handle("/person/{id}", withPermission("person:write", person.Update)).Methods("PUT")
+
+	router.Handle("/roles", withPermission("rbac:manage", middleware.WithDB(rbac.ListRoles))).Methods("GET")
+	router.Handle("/roles", withPermission("rbac:manage", middleware.WithDB(rbac.CreateRole))).Methods("POST")
+	router.Handle("/roles/{id}", withPermission("rbac:manage", middleware.WithDB(rbac.DeleteRole))).Methods("DELETE")
+	router.Handle("/roles/{id}/permissions", withPermission("rbac:manage", middleware.WithDB(rbac.AddPermission))).Methods("POST")
+	router.Handle("/roles/{id}/permissions/{permission}", withPermission("rbac:manage", middleware.WithDB(rbac.RemovePermission))).Methods("DELETE")
+	router.Handle("/users/{id}/roles", withPermission("rbac:manage", middleware.WithDB(rbac.AssignRole))).Methods("POST")
+	router.Handle("/users/{id}/roles/{roleId}", withPermission("rbac:manage", middleware.WithDB(rbac.RevokeRole))).Methods("DELETE")
+
+	router.Handle("/admin/replication/policies", authorizeAdmin(http.HandlerFunc(replicationHandlers.List))).Methods("GET")
+	router.Handle("/admin/replication/policies", authorizeAdmin(http.HandlerFunc(replicationHandlers.Create))).Methods("POST")
+	router.Handle("/admin/replication/policies/{id}", authorizeAdmin(http.HandlerFunc(replicationHandlers.Update))).Methods("PUT")
+	router.Handle("/admin/replication/policies/{id}", authorizeAdmin(http.HandlerFunc(replicationHandlers.Delete))).Methods("DELETE")
+	router.Handle("/admin/replication/policies/{id}/trigger", authorizeAdmin(http.HandlerFunc(replicationHandlers.Trigger))).Methods("POST")
+
+	router.Handle("/admin/config", authorizeAdmin(http.HandlerFunc(configHandlers.Patch))).Methods("PATCH")
+
+	log.Fatal(http.ListenAndServe(cfg.ListenAddr, router))
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// ServiceClaims defines the claims that should be received by the JWT token
+type ServiceClaims struct {
+	UserName string `json:"username"`
+	Tenant   string `json:"tenant"`
+	Role     string `json:"role"`
+	jwt.StandardClaims
+}
+
+// User defines a tenant-scoped account that can authenticate against the API
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username" gorm:"unique_index"`
+	Password string `json:"-"`
+	Tenant   string `json:"tenant"`
+	Role     string `json:"role"`
+}
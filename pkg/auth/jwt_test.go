@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+func TestKeyFuncRotation(t *testing.T) {
+	t.Cleanup(func() {
+		Configure(map[string]string{"default": "c0e18b6a-a204-4197-93f4-c11f6cd5bad8"}, "default")
+	})
+
+	if err := Configure(map[string]string{
+		"old": "old-secret",
+		"new": "new-secret",
+	}, "new"); err != nil {
+		t.Fatalf("Configure: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		kid     interface{}
+		wantKey string
+		wantErr bool
+	}{
+		{"resolves a known, non-signing kid", "old", "old-secret", false},
+		{"resolves the current signing kid", "new", "new-secret", false},
+		{"falls back to the signing kid when the token has none", nil, "new-secret", false},
+		{"falls back to the signing kid when the kid header isn't a string", 42, "new-secret", false},
+		{"rejects an unknown kid", "unknown", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &jwt.Token{Header: map[string]interface{}{}}
+
+			if tt.kid != nil {
+				token.Header["kid"] = tt.kid
+			}
+
+			key, err := KeyFunc(token)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("KeyFunc() = %v, nil, want an error", key)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("KeyFunc(): unexpected error: %v", err)
+			}
+
+			if got := string(key.([]byte)); got != tt.wantKey {
+				t.Errorf("KeyFunc() key = %q, want %q", got, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestConfigureRejectsUnknownSigningKid(t *testing.T) {
+	if err := Configure(map[string]string{"old": "old-secret"}, "missing"); err == nil {
+		t.Fatal("Configure() with a signing kid absent from the secret set: want an error, got nil")
+	}
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/db"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/rbac"
+)
+
+// loginRequest is the payload expected by the login endpoint
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Tenant   string `json:"tenant"`
+}
+
+// registerRequest is the payload expected by the register endpoint
+type registerRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Tenant   string `json:"tenant"`
+}
+
+// tokenResponse wraps a signed JWT returned by the login and refresh endpoints
+type tokenResponse struct {
+	Token string `json:"token"`
+}
+
+// Service exposes the login and refresh HTTP endpoints, backed by a tenant pool
+type Service struct {
+	pool *db.TenantPool
+}
+
+// NewService creates an auth Service backed by pool
+func NewService(pool *db.TenantPool) *Service {
+	return &Service{pool: pool}
+}
+
+// Login binds the request to a username, password and tenant, verifies the
+// credentials against that tenant's Users table, and returns a freshly signed JWT
+func (s *Service) Login(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Username == "" || req.Password == "" || req.Tenant == "" {
+		http.Error(w, "username, password and tenant are required", http.StatusBadRequest)
+		return
+	}
+
+	if !db.ValidTenant(req.Tenant) {
+		http.Error(w, "invalid tenant", http.StatusBadRequest)
+		return
+	}
+
+	var user User
+
+	err := s.pool.WithTenant(req.Tenant, func(tx *gorm.DB) error {
+		return tx.Where("username = ?", req.Username).First(&user).Error
+	})
+
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+
+	claims := ServiceClaims{
+		UserName: user.Username,
+		Tenant:   user.Tenant,
+		Role:     user.Role,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(TokenTTL).Unix(),
+		},
+	}
+
+	tokenString, err := SignToken(claims)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Could not sign token: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse{Token: tokenString})
+}
+
+// Register creates a new user under a tenant and hashes its password. The
+// very first user registered for a tenant is assigned that tenant's "admin"
+// role (seeded by rbac.SeedAdminRole when the tenant schema was migrated),
+// since without it nothing could ever hold rbac:manage to grant roles afterwards
+func (s *Service) Register(w http.ResponseWriter, r *http.Request) {
+	var req registerRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Username == "" || req.Password == "" || req.Tenant == "" {
+		http.Error(w, "username, password and tenant are required", http.StatusBadRequest)
+		return
+	}
+
+	if !db.ValidTenant(req.Tenant) {
+		http.Error(w, "invalid tenant", http.StatusBadRequest)
+		return
+	}
+
+	var user User
+	taken := false
+
+	err := s.pool.WithTenant(req.Tenant, func(tx *gorm.DB) error {
+		var existing User
+
+		if err := tx.Where("username = ?", req.Username).First(&existing).Error; err == nil {
+			taken = true
+			return fmt.Errorf("username %q is already taken", req.Username)
+		}
+
+		var userCount int
+
+		if err := tx.Model(&User{}).Count(&userCount).Error; err != nil {
+			return err
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+
+		if err != nil {
+			return err
+		}
+
+		user = User{Username: req.Username, Password: string(hashed), Tenant: req.Tenant}
+
+		if userCount == 0 {
+			user.Role = "admin"
+		}
+
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		if userCount == 0 {
+			var role rbac.Role
+
+			if err := tx.Where(rbac.Role{Name: "admin"}).First(&role).Error; err != nil {
+				return err
+			}
+
+			return tx.Create(&rbac.UserRole{UserID: user.ID, RoleID: role.ID}).Error
+		}
+
+		return nil
+	})
+
+	if taken {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Could not register user: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// Refresh re-signs a still-valid token with a new expiry, so a client can keep
+// a session alive without asking the user for their credentials again
+func (s *Service) Refresh(w http.ResponseWriter, r *http.Request) {
+	claims, err := ParseToken(r.Header.Get("Authorization"))
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Token is not valid: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	claims.IssuedAt = time.Now().Unix()
+	claims.ExpiresAt = time.Now().Add(TokenTTL).Unix()
+
+	tokenString, err := SignToken(*claims)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Could not sign token: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse{Token: tokenString})
+}
@@ -0,0 +1,207 @@
+// Package config centralizes the service's runtime configuration, loading it
+// from a YAML file, environment variables and flags, and supporting safe
+// live reload via Manager.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config holds the settings needed to wire the service together
+type Config struct {
+	ListenAddr         string            `yaml:"listen_addr"`
+	DatabaseDSN        string            `yaml:"database_dsn"`
+	PoolMaxOpenConns   int               `yaml:"pool_max_open_conns"`
+	PoolIdleTTLSeconds int               `yaml:"pool_idle_ttl_seconds"`
+	AutoMigrate        bool              `yaml:"auto_migrate"`
+	JWTSecrets         map[string]string `yaml:"jwt_secrets"`
+	JWTSigningKid      string            `yaml:"jwt_signing_kid"`
+}
+
+// defaults returns the configuration used when neither the file, the
+// environment nor flags say otherwise
+func defaults() *Config {
+	return &Config{
+		ListenAddr:         ":8000",
+		DatabaseDSN:        "host=localhost port=5432 user=postgres dbname=postgres password='password' sslmode=disable",
+		PoolMaxOpenConns:   10,
+		PoolIdleTTLSeconds: 300,
+		AutoMigrate:        true,
+		JWTSecrets:         map[string]string{"default": "c0e18b6a-a204-4197-93f4-c11f6cd5bad8"},
+		JWTSigningKid:      "default",
+	}
+}
+
+// loadEnv gets an environment variable value, otherwise returns the defined default value
+func loadEnv(envName string, defaultValue string) string {
+	value, ok := os.LookupEnv(envName)
+
+	if !ok {
+		value = defaultValue
+	}
+
+	return value
+}
+
+// loadEnvInt behaves like loadEnv, but parses the environment variable as an
+// int, falling back to defaultValue if it is unset or not a valid int
+func loadEnvInt(envName string, defaultValue int) int {
+	value, ok := os.LookupEnv(envName)
+
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+
+	if err != nil {
+		log.Printf("config: %s=%q is not a valid int, ignoring", envName, value)
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// loadEnvBool behaves like loadEnv, but parses the environment variable as a
+// bool, falling back to defaultValue if it is unset or not a valid bool
+func loadEnvBool(envName string, defaultValue bool) bool {
+	value, ok := os.LookupEnv(envName)
+
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+
+	if err != nil {
+		log.Printf("config: %s=%q is not a valid bool, ignoring", envName, value)
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// parseSecrets parses a "kid=secret,kid2=secret2" list into a kid->secret map
+func parseSecrets(raw string) (map[string]string, error) {
+	secrets := map[string]string{}
+
+	for _, pair := range strings.Split(raw, ",") {
+		kid, secret, found := strings.Cut(pair, "=")
+
+		if !found || kid == "" || secret == "" {
+			return nil, fmt.Errorf("malformed entry %q, expected kid=secret", pair)
+		}
+
+		secrets[kid] = secret
+	}
+
+	return secrets, nil
+}
+
+// loadEnvSecrets parses a JWT_SECRETS value of the form "kid=secret,kid2=secret2"
+// into a kid->secret map, falling back to defaultValue if it is unset or malformed
+func loadEnvSecrets(envName string, defaultValue map[string]string) map[string]string {
+	value, ok := os.LookupEnv(envName)
+
+	if !ok {
+		return defaultValue
+	}
+
+	secrets, err := parseSecrets(value)
+
+	if err != nil {
+		log.Printf("config: %s is invalid (%v), ignoring", envName, err)
+		return defaultValue
+	}
+
+	return secrets
+}
+
+// loadFile overlays cfg with the contents of the YAML file at path, if present
+func loadFile(cfg *Config, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		log.Printf("config: %s does not exist, using defaults/env/flags instead", path)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(raw, cfg)
+}
+
+// loadFlags overlays cfg with values parsed from args, defaulting every flag
+// to whatever cfg already holds so an unset flag leaves the file/env value in place
+func loadFlags(cfg *Config, args []string) error {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+
+	listenAddr := fs.String("listen-addr", cfg.ListenAddr, "address the server listens on")
+	databaseDSN := fs.String("database-dsn", cfg.DatabaseDSN, "DSN used to open the base database connection")
+	poolMaxOpenConns := fs.Int("pool-max-open-conns", cfg.PoolMaxOpenConns, "max open connections kept per tenant")
+	poolIdleTTLSeconds := fs.Int("pool-idle-ttl-seconds", cfg.PoolIdleTTLSeconds, "seconds an idle tenant connection is kept before being closed")
+	autoMigrate := fs.Bool("auto-migrate", cfg.AutoMigrate, "automatically run AutoMigrate against each tenant schema on first use")
+	jwtSecrets := fs.String("jwt-secrets", "", "comma-separated kid=secret list of accepted JWT signing keys")
+	jwtSigningKid := fs.String("jwt-signing-kid", cfg.JWTSigningKid, "kid used to sign newly issued JWTs")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg.ListenAddr = *listenAddr
+	cfg.DatabaseDSN = *databaseDSN
+	cfg.PoolMaxOpenConns = *poolMaxOpenConns
+	cfg.PoolIdleTTLSeconds = *poolIdleTTLSeconds
+	cfg.AutoMigrate = *autoMigrate
+	cfg.JWTSigningKid = *jwtSigningKid
+
+	if *jwtSecrets != "" {
+		secrets, err := parseSecrets(*jwtSecrets)
+
+		if err != nil {
+			return fmt.Errorf("--jwt-secrets: %v", err)
+		}
+
+		cfg.JWTSecrets = secrets
+	}
+
+	return nil
+}
+
+// Load builds a Config by starting from defaults, overlaying the YAML file
+// at path (if any), then environment variables, then args
+func Load(path string, args []string) (*Config, error) {
+	cfg := defaults()
+
+	if err := loadFile(cfg, path); err != nil {
+		return nil, err
+	}
+
+	cfg.ListenAddr = loadEnv("LISTEN_ADDR", cfg.ListenAddr)
+	cfg.DatabaseDSN = loadEnv("DATABASE_DSN", cfg.DatabaseDSN)
+	cfg.PoolMaxOpenConns = loadEnvInt("POOL_MAX_OPEN_CONNS", cfg.PoolMaxOpenConns)
+	cfg.PoolIdleTTLSeconds = loadEnvInt("POOL_IDLE_TTL_SECONDS", cfg.PoolIdleTTLSeconds)
+	cfg.AutoMigrate = loadEnvBool("AUTO_MIGRATE", cfg.AutoMigrate)
+	cfg.JWTSecrets = loadEnvSecrets("JWT_SECRETS", cfg.JWTSecrets)
+	cfg.JWTSigningKid = loadEnv("JWT_SIGNING_KID", cfg.JWTSigningKid)
+
+	if err := loadFlags(cfg, args); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// patchRequest is the payload expected by the admin config-patch endpoint.
+// A non-empty Fingerprint must match the config's current Fingerprint,
+// rejecting the patch if another admin changed the config since it was read;
+// an empty Fingerprint skips that check. Fields left nil are left unchanged
+type patchRequest struct {
+	Fingerprint        string            `json:"fingerprint"`
+	PoolMaxOpenConns   *int              `json:"pool_max_open_conns,omitempty"`
+	PoolIdleTTLSeconds *int              `json:"pool_idle_ttl_seconds,omitempty"`
+	AutoMigrate        *bool             `json:"auto_migrate,omitempty"`
+	JWTSecrets         map[string]string `json:"jwt_secrets,omitempty"`
+	JWTSigningKid      *string           `json:"jwt_signing_kid,omitempty"`
+}
+
+// patchResponse reports the config's new fingerprint after a successful patch
+type patchResponse struct {
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Handlers exposes the admin HTTP endpoint used to patch the live Config
+type Handlers struct {
+	manager *Manager
+}
+
+// NewHandlers creates a config Handlers backed by manager
+func NewHandlers(manager *Manager) *Handlers {
+	return &Handlers{manager: manager}
+}
+
+// Patch applies a partial update to the live Config under the optimistic
+// concurrency DoLockedAction provides, rejecting the request with 409 if the
+// config changed since the caller last read its fingerprint
+func (h *Handlers) Patch(w http.ResponseWriter, r *http.Request) {
+	var req patchRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	newFingerprint, err := h.manager.DoLockedAction(req.Fingerprint, func(cfg *Config) error {
+		if req.PoolMaxOpenConns != nil {
+			cfg.PoolMaxOpenConns = *req.PoolMaxOpenConns
+		}
+
+		if req.PoolIdleTTLSeconds != nil {
+			cfg.PoolIdleTTLSeconds = *req.PoolIdleTTLSeconds
+		}
+
+		if req.AutoMigrate != nil {
+			cfg.AutoMigrate = *req.AutoMigrate
+		}
+
+		if req.JWTSecrets != nil {
+			cfg.JWTSecrets = req.JWTSecrets
+		}
+
+		if req.JWTSigningKid != nil {
+			cfg.JWTSigningKid = *req.JWTSigningKid
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, ErrFingerprintMismatch) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Could not patch config: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(patchResponse{Fingerprint: newFingerprint})
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrFingerprintMismatch is returned by DoLockedAction when expectedFingerprint
+// does not match the Config's current fingerprint, so callers (e.g. an admin
+// HTTP handler) can tell a stale write apart from any other failure
+var ErrFingerprintMismatch = errors.New("config has changed since the fingerprint was read")
+
+// ChangeFunc is notified with the new Config whenever it changes, either
+// because the backing file was edited or because DoLockedAction ran
+type ChangeFunc func(*Config)
+
+// Manager owns the live Config, reloading it from its backing file and
+// serializing mutations so subsystems can safely subscribe to changes
+type Manager struct {
+	mu          sync.Mutex
+	path        string
+	args        []string
+	current     *Config
+	subscribers []ChangeFunc
+	watcher     *fsnotify.Watcher
+}
+
+// NewManager loads the initial Config from path/args and returns a Manager for it
+func NewManager(path string, args []string) (*Manager, error) {
+	cfg, err := Load(path, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{path: path, args: args, current: cfg}, nil
+}
+
+// Get returns a copy of the current Config
+func (m *Manager) Get() *Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg := *m.current
+
+	return &cfg
+}
+
+// Fingerprint returns the sha256 of the current Config, used by callers to
+// detect whether it changed since they last read it
+func (m *Manager) Fingerprint() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return fingerprint(m.current)
+}
+
+func fingerprint(cfg *Config) string {
+	raw, _ := json.Marshal(cfg)
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Subscribe registers fn to be called with the new Config every time it changes
+func (m *Manager) Subscribe(fn ChangeFunc) {
+	m.mu.Lock()
+	m.subscribers = append(m.subscribers, fn)
+	m.mu.Unlock()
+}
+
+func (m *Manager) notify(cfg *Config) {
+	m.mu.Lock()
+	subscribers := append([]ChangeFunc{}, m.subscribers...)
+	m.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(cfg)
+	}
+}
+
+// DoLockedAction applies fn to a copy of the current Config under a lock,
+// rejecting the mutation if expectedFingerprint does not match the Config's
+// current fingerprint (optimistic concurrency for an admin endpoint patching
+// the live config). An empty expectedFingerprint skips the check. On success it stores the mutated Config, notifies
+// subscribers, and returns its new fingerprint
+func (m *Manager) DoLockedAction(expectedFingerprint string, fn func(*Config) error) (string, error) {
+	m.mu.Lock()
+
+	if expectedFingerprint != "" && fingerprint(m.current) != expectedFingerprint {
+		m.mu.Unlock()
+		return "", fmt.Errorf("%w: expected %s", ErrFingerprintMismatch, expectedFingerprint)
+	}
+
+	next := *m.current
+
+	if err := fn(&next); err != nil {
+		m.mu.Unlock()
+		return "", err
+	}
+
+	m.current = &next
+	newFingerprint := fingerprint(m.current)
+	m.mu.Unlock()
+
+	m.notify(&next)
+
+	return newFingerprint, nil
+}
+
+// Watch starts watching the backing file for writes, reloading the Config
+// and notifying subscribers whenever it changes. It is a no-op when the
+// Manager was created without a file path
+func (m *Manager) Watch() error {
+	if m.path == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return err
+	}
+
+	if err := watcher.Add(m.path); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	m.watcher = watcher
+
+	go func() {
+		for event := range watcher.Events {
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			cfg, err := Load(m.path, m.args)
+
+			if err != nil {
+				log.Printf("config: failed to reload %s: %v", m.path, err)
+				continue
+			}
+
+			m.mu.Lock()
+			m.current = cfg
+			m.mu.Unlock()
+
+			log.Printf("config: reloaded from %s", m.path)
+			m.notify(cfg)
+		}
+	}()
+
+	return nil
+}
+
+// Close stops the file watcher, if one was started
+func (m *Manager) Close() error {
+	if m.watcher == nil {
+		return nil
+	}
+
+	return m.watcher.Close()
+}
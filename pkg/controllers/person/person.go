@@ -0,0 +1,120 @@
+// Package person implements the CRUD endpoints for the Person resource.
+package person
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/middleware"
+)
+
+// Person defines the basic struct of a person
+type Person struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// List is a handler that returns a list of Person
+func List(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	var people []Person
+
+	db.Find(&people)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(people)
+}
+
+// Get is a handler that returns a single Person based on the informed ID
+func Get(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	params := mux.Vars(r)
+	var person Person
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db.Where("id = ?", id).Find(&person)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(person)
+}
+
+// Create is a handler that creates a new Person with the informed ID
+func Create(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	params := mux.Vars(r)
+	var person Person
+	var personList []Person
+
+	_ = json.NewDecoder(r.Body).Decode(&person)
+
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	person.ID = id
+
+	db.Create(&person)
+	db.Find(&personList)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(personList)
+}
+
+// Delete is a handler that deletes the person with the informed ID
+func Delete(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	params := mux.Vars(r)
+	var person Person
+	var personList []Person
+
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	person.ID = id
+
+	db.Delete(&person)
+	db.Find(&personList)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(personList)
+}
+
+// Update updates the person with the informed ID
+func Update(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	params := mux.Vars(r)
+	var person Person
+	var personList []Person
+
+	_ = json.NewDecoder(r.Body).Decode(&person)
+
+	id, err := strconv.Atoi(params["id"])
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	person.ID = id
+
+	db.Save(&person)
+	db.Find(&personList)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(personList)
+}
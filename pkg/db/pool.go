@@ -0,0 +1,260 @@
+// Package db provides the per-tenant connection pool used to isolate each
+// tenant's data in its own Postgres schema.
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+)
+
+// tenantPattern restricts tenant identifiers to what is safe to splice into a
+// DSN's search_path parameter and into "create schema"/"set search_path" DDL.
+// Tenant names ultimately come from request input (e.g. the login/register
+// payload), so anything outside this pattern must never reach a query or DSN
+var tenantPattern = regexp.MustCompile(`^[a-z0-9_]{1,63}$`)
+
+// ValidTenant reports whether tenant is safe to use as a schema name and as a
+// DSN search_path value
+func ValidTenant(tenant string) bool {
+	return tenantPattern.MatchString(tenant)
+}
+
+// tenantConn tracks a tenant's connection together with the last time it was
+// used, so the reaper can evict connections nobody is touching anymore
+type tenantConn struct {
+	db           *gorm.DB
+	lastAccessed time.Time
+}
+
+// SystemSchema is the reserved, non-tenant schema used for service-wide state
+// (e.g. replication policies) that must not be duplicated into every tenant
+const SystemSchema = "system"
+
+// TenantPool lazily opens and caches one *gorm.DB per tenant, each scoped to
+// that tenant's schema via search_path, instead of serializing every tenant
+// onto a single shared pool. Idle tenants are evicted after idleTTL so a
+// long-running process does not keep accumulating open connections
+type TenantPool struct {
+	mu             sync.Mutex
+	baseDSN        string
+	maxOpenConns   int
+	idleTTL        time.Duration
+	reapInterval   time.Duration
+	autoMigrate    bool
+	models         []interface{}
+	migrationHooks []func(tenant string, db *gorm.DB)
+	conns          map[string]*tenantConn
+	migrated       sync.Map // tenant -> *sync.Once, guards AutoMigrate per tenant
+	systemOnce     sync.Once
+}
+
+// NewTenantPool creates a TenantPool that opens connections against baseDSN,
+// scoping each one to a tenant's schema, and starts its background reaper.
+// When autoMigrate is false, a tenant's schema is still created on first use,
+// but its models are not migrated into it - useful in environments where
+// migrations are run out-of-band instead of by the service itself
+func NewTenantPool(baseDSN string, maxOpenConns int, idleTTL time.Duration, autoMigrate bool) *TenantPool {
+	pool := &TenantPool{
+		baseDSN:      baseDSN,
+		maxOpenConns: maxOpenConns,
+		idleTTL:      idleTTL,
+		reapInterval: time.Minute,
+		autoMigrate:  autoMigrate,
+		conns:        map[string]*tenantConn{},
+	}
+
+	go pool.reap()
+
+	return pool
+}
+
+// RegisterModels adds models that must be migrated into every tenant schema
+// on first use. It must be called before the first call to For
+func (p *TenantPool) RegisterModels(models ...interface{}) {
+	p.models = append(p.models, models...)
+}
+
+// RegisterMigrationHook adds a function that runs once per tenant, right
+// after that tenant's schema has been migrated - e.g. seeding default rows
+// a freshly created schema needs. It must be called before the first call to For
+func (p *TenantPool) RegisterMigrationHook(hook func(tenant string, db *gorm.DB)) {
+	p.migrationHooks = append(p.migrationHooks, hook)
+}
+
+// Resize changes the per-tenant open connection cap, applying it to every
+// connection already open as well as ones opened afterwards. This lets
+// config.Manager resize the pool without a restart
+func (p *TenantPool) Resize(maxOpenConns int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.maxOpenConns = maxOpenConns
+
+	for _, conn := range p.conns {
+		conn.db.DB().SetMaxOpenConns(maxOpenConns)
+	}
+}
+
+// SetAutoMigrate changes whether tenants migrated after this call run
+// AutoMigrate/the registered migration hooks. Tenants migrated before the
+// call are unaffected, since migrate only ever runs once per tenant
+func (p *TenantPool) SetAutoMigrate(autoMigrate bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.autoMigrate = autoMigrate
+}
+
+// For returns the cached *gorm.DB for tenant, opening and migrating one on
+// first use
+func (p *TenantPool) For(tenant string) (*gorm.DB, error) {
+	if !ValidTenant(tenant) {
+		return nil, fmt.Errorf("invalid tenant: %q", tenant)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, ok := p.conns[tenant]; ok {
+		conn.lastAccessed = time.Now()
+		return conn.db, nil
+	}
+
+	db, err := gorm.Open("postgres", fmt.Sprintf("%s search_path=%s", p.baseDSN, tenant))
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.DB().SetMaxOpenConns(p.maxOpenConns)
+
+	p.conns[tenant] = &tenantConn{db: db, lastAccessed: time.Now()}
+
+	p.migrate(tenant, db)
+
+	return db, nil
+}
+
+// SystemDB returns the connection scoped to the reserved system schema,
+// creating it on first use. Unlike For, it never runs the per-tenant model
+// migration registered via RegisterModels - callers migrate their own
+// system-schema models explicitly
+func (p *TenantPool) SystemDB() (*gorm.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, ok := p.conns[SystemSchema]; ok {
+		conn.lastAccessed = time.Now()
+		return conn.db, nil
+	}
+
+	db, err := gorm.Open("postgres", fmt.Sprintf("%s search_path=%s", p.baseDSN, SystemSchema))
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.DB().SetMaxOpenConns(p.maxOpenConns)
+
+	p.systemOnce.Do(func() {
+		db.Exec("create schema if not exists " + SystemSchema)
+		db.Exec("set search_path to " + SystemSchema)
+	})
+
+	p.conns[SystemSchema] = &tenantConn{db: db, lastAccessed: time.Now()}
+
+	return db, nil
+}
+
+// migrate creates the tenant's schema and runs AutoMigrate exactly once per
+// tenant for the lifetime of the pool, instead of on every request. It is
+// only ever called by For, which already holds p.mu
+func (p *TenantPool) migrate(tenant string, db *gorm.DB) {
+	if !ValidTenant(tenant) {
+		return
+	}
+
+	once, _ := p.migrated.LoadOrStore(tenant, &sync.Once{})
+
+	once.(*sync.Once).Do(func() {
+		db.Exec("create schema if not exists " + tenant)
+		db.Exec("set search_path to " + tenant)
+
+		if !p.autoMigrate {
+			return
+		}
+
+		if len(p.models) > 0 {
+			db.AutoMigrate(p.models...)
+		}
+
+		for _, hook := range p.migrationHooks {
+			hook(tenant, db)
+		}
+	})
+}
+
+// reap periodically closes and evicts tenant connections that have been idle
+// for longer than idleTTL
+func (p *TenantPool) reap() {
+	ticker := time.NewTicker(p.reapInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.mu.Lock()
+
+		for tenant, conn := range p.conns {
+			if time.Since(conn.lastAccessed) > p.idleTTL {
+				conn.db.Close()
+				delete(p.conns, tenant)
+			}
+		}
+
+		p.mu.Unlock()
+	}
+}
+
+// WithTenant borrows the tenant's pooled connection, starts a transaction on
+// it, and runs fn against it, committing or rolling back based on the error it returns
+func (p *TenantPool) WithTenant(tenant string, fn func(db *gorm.DB) error) error {
+	conn, err := p.For(tenant)
+
+	if err != nil {
+		return err
+	}
+
+	return withTx(conn, fn)
+}
+
+// WithSystem borrows the system schema's pooled connection, starts a
+// transaction on it, and runs fn against it, committing or rolling back
+// based on the error it returns
+func (p *TenantPool) WithSystem(fn func(db *gorm.DB) error) error {
+	conn, err := p.SystemDB()
+
+	if err != nil {
+		return err
+	}
+
+	return withTx(conn, fn)
+}
+
+func withTx(conn *gorm.DB, fn func(db *gorm.DB) error) error {
+	tx := conn.Begin()
+
+	err := fn(tx)
+
+	if err == nil {
+		tx.Commit()
+	} else {
+		tx.Rollback()
+	}
+
+	return err
+}
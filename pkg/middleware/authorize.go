@@ -0,0 +1,132 @@
+// Package middleware provides HTTP middleware shared across controllers.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/auth"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/db"
+)
+
+type contextKey int
+
+const (
+	dbContextKey contextKey = iota
+	claimsContextKey
+)
+
+// statusRecorder wraps a http.ResponseWriter to remember the status code the
+// handler wrote, so Authorize knows whether to commit or roll back
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// DBFromContext returns the *gorm.DB transaction that Authorize opened for
+// the current tenant
+func DBFromContext(ctx context.Context) *gorm.DB {
+	return ctx.Value(dbContextKey).(*gorm.DB)
+}
+
+// ClaimsFromContext returns the claims of the token that Authorize validated
+func ClaimsFromContext(ctx context.Context) *auth.ServiceClaims {
+	return ctx.Value(claimsContextKey).(*auth.ServiceClaims)
+}
+
+// WithDB adapts a handler that takes the request's tenant transaction as an
+// explicit argument into a plain http.HandlerFunc, so packages that must not
+// import middleware (e.g. pkg/rbac, to avoid an import cycle with it) can
+// still receive the transaction Authorize put in the request context
+func WithDB(fn func(db *gorm.DB, w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fn(DBFromContext(r.Context()), w, r)
+	}
+}
+
+// Authorize validates the request's JWT, opens a transaction scoped to the
+// claimed tenant, and makes both the claims and the transaction available to
+// next via the request context. The transaction is committed if next writes
+// a non-error status code, and rolled back otherwise
+func Authorize(pool *db.TenantPool) func(http.Handler) http.Handler {
+	return authorize(func(claims *auth.ServiceClaims, fn func(*gorm.DB) error) error {
+		return pool.WithTenant(claims.Tenant, fn)
+	})
+}
+
+// AuthorizeSystem behaves like Authorize, but always scopes the transaction
+// to the system schema instead of the token's tenant. It is used by admin
+// endpoints whose data (e.g. replication policies) is not tenant-owned
+func AuthorizeSystem(pool *db.TenantPool) func(http.Handler) http.Handler {
+	return authorize(func(claims *auth.ServiceClaims, fn func(*gorm.DB) error) error {
+		return pool.WithSystem(fn)
+	})
+}
+
+func authorize(runWithin func(claims *auth.ServiceClaims, fn func(*gorm.DB) error) error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := auth.ParseToken(r.Header.Get("Authorization"))
+
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Token is not valid: %v", err), http.StatusUnauthorized)
+				return
+			}
+
+			handlerRan := false
+
+			err = runWithin(claims, func(tx *gorm.DB) error {
+				handlerRan = true
+
+				ctx := context.WithValue(r.Context(), dbContextKey, tx)
+				ctx = context.WithValue(ctx, claimsContextKey, claims)
+
+				rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+				next.ServeHTTP(rec, r.WithContext(ctx))
+
+				if rec.status >= 400 {
+					return fmt.Errorf("handler responded with status %d", rec.status)
+				}
+
+				return nil
+			})
+
+			if handlerRan {
+				// next already wrote the response; the transaction was either
+				// committed or rolled back because it returned an error status
+				return
+			}
+
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Could not open tenant transaction: %v", err), http.StatusInternalServerError)
+			}
+		})
+	}
+}
+
+// RequireRole rejects the request with 403 unless the authorized claims carry
+// the given role. It must run after Authorize/AuthorizeSystem so claims are
+// already in the request context
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := ClaimsFromContext(r.Context())
+
+			if claims.Role != role {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
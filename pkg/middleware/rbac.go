@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/auth"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/rbac"
+)
+
+// RequirePermission rejects the request with 403 unless the caller holds
+// permission. It must run after Authorize, which is what populates the
+// request context with the claims and tenant transaction this middleware
+// loads permissions from
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := ClaimsFromContext(r.Context())
+			tx := DBFromContext(r.Context())
+
+			var user auth.User
+
+			if err := tx.Where("username = ?", claims.UserName).First(&user).Error; err != nil {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			granted, err := rbac.EffectivePermissions(tx, user.ID)
+
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Could not load permissions: %v", err), http.StatusInternalServerError)
+				return
+			}
+
+			if !rbac.Allows(granted, permission) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package rbac
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// adminPermission is the superuser wildcard, granting every action on every resource
+const adminPermission = "*:*"
+
+// SeedAdminRole ensures an "admin" role exists with the "*:*" permission. It
+// is meant to run once per tenant schema, right after that schema is migrated
+func SeedAdminRole(db *gorm.DB) error {
+	var role Role
+
+	if err := db.Where(Role{Name: "admin"}).FirstOrCreate(&role, Role{Name: "admin"}).Error; err != nil {
+		return err
+	}
+
+	var count int
+	db.Model(&RolePermission{}).Where("role_id = ? and permission = ?", role.ID, adminPermission).Count(&count)
+
+	if count > 0 {
+		return nil
+	}
+
+	return db.Create(&RolePermission{RoleID: role.ID, Permission: adminPermission}).Error
+}
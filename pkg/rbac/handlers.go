@@ -0,0 +1,199 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+type roleRequest struct {
+	Name string `json:"name"`
+}
+
+type permissionRequest struct {
+	Permission string `json:"permission"`
+}
+
+type assignRequest struct {
+	RoleID int `json:"role_id"`
+}
+
+// roleView is a Role together with the permissions currently granted to it
+type roleView struct {
+	Role
+	Permissions []string `json:"permissions"`
+}
+
+// ListRoles returns every role defined for the tenant, along with its permissions
+func ListRoles(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	var roles []Role
+
+	db.Find(&roles)
+
+	views := make([]roleView, 0, len(roles))
+
+	for _, role := range roles {
+		var permissions []RolePermission
+		db.Where("role_id = ?", role.ID).Find(&permissions)
+
+		names := make([]string, len(permissions))
+
+		for i, permission := range permissions {
+			names[i] = permission.Permission
+		}
+
+		views = append(views, roleView{Role: role, Permissions: names})
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// CreateRole creates a new, empty role
+func CreateRole(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	role := Role{Name: req.Name}
+
+	if err := db.Create(&role).Error; err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(role)
+}
+
+// DeleteRole removes a role along with its permissions and user assignments
+func DeleteRole(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	id, err := idFromRequest(r, "id")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db.Where("role_id = ?", id).Delete(&RolePermission{})
+	db.Where("role_id = ?", id).Delete(&UserRole{})
+	db.Delete(&Role{}, "id = ?", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddPermission grants a permission (e.g. "person:write") to a role
+func AddPermission(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	roleID, err := idFromRequest(r, "id")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req permissionRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Permission == "" {
+		http.Error(w, "permission is required", http.StatusBadRequest)
+		return
+	}
+
+	grant := RolePermission{RoleID: roleID, Permission: req.Permission}
+
+	if err := db.Create(&grant).Error; err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grant)
+}
+
+// RemovePermission revokes a permission from a role
+func RemovePermission(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	roleID, err := idFromRequest(r, "id")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db.Where("role_id = ? and permission = ?", roleID, mux.Vars(r)["permission"]).Delete(&RolePermission{})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AssignRole grants a role to a user
+func AssignRole(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	userID, err := idFromRequest(r, "id")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req assignRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	assignment := UserRole{UserID: userID, RoleID: req.RoleID}
+
+	if err := db.Create(&assignment).Error; err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignment)
+}
+
+// RevokeRole removes a role from a user
+func RevokeRole(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	userID, err := idFromRequest(r, "id")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	roleID, err := idFromRequest(r, "roleId")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db.Where("user_id = ? and role_id = ?", userID, roleID).Delete(&UserRole{})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func idFromRequest(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", name, err)
+	}
+
+	return id, nil
+}
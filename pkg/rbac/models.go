@@ -0,0 +1,25 @@
+// Package rbac implements resource-level permission checks on top of the
+// tenant-scoped roles a user is assigned.
+package rbac
+
+// Role is a named group of permissions, defined per tenant schema
+type Role struct {
+	ID   int    `json:"id"`
+	Name string `json:"name" gorm:"unique_index"`
+}
+
+// RolePermission grants a role a single permission, expressed as
+// "resource:action" (e.g. "person:read"). "*:*" grants everything and
+// "resource:*" grants every action on one resource; "*:action" is not a wildcard
+type RolePermission struct {
+	ID         int    `json:"id"`
+	RoleID     int    `json:"role_id"`
+	Permission string `json:"permission"`
+}
+
+// UserRole assigns a role to a user
+type UserRole struct {
+	ID     int `json:"id"`
+	UserID int `json:"user_id"`
+	RoleID int `json:"role_id"`
+}
@@ -0,0 +1,51 @@
+package rbac
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// EffectivePermissions returns the set of permissions granted to userID
+// across every role assigned to it
+func EffectivePermissions(db *gorm.DB, userID int) (map[string]bool, error) {
+	var roleIDs []int
+
+	if err := db.Model(&UserRole{}).Where("user_id = ?", userID).Pluck("role_id", &roleIDs).Error; err != nil {
+		return nil, err
+	}
+
+	granted := map[string]bool{}
+
+	if len(roleIDs) == 0 {
+		return granted, nil
+	}
+
+	var permissions []RolePermission
+
+	if err := db.Where("role_id in (?)", roleIDs).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	for _, permission := range permissions {
+		granted[permission.Permission] = true
+	}
+
+	return granted, nil
+}
+
+// Allows reports whether granted includes permission, honoring the "*:*"
+// superuser wildcard and a per-resource "resource:*" wildcard
+func Allows(granted map[string]bool, permission string) bool {
+	if granted["*:*"] {
+		return true
+	}
+
+	if granted[permission] {
+		return true
+	}
+
+	resource := strings.SplitN(permission, ":", 2)[0]
+
+	return granted[resource+":*"]
+}
@@ -0,0 +1,94 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name       string
+		granted    map[string]bool
+		permission string
+		want       bool
+	}{
+		{"exact match", map[string]bool{"person:read": true}, "person:read", true},
+		{"no match", map[string]bool{"person:read": true}, "person:write", false},
+		{"superuser wildcard", map[string]bool{"*:*": true}, "anything:goes", true},
+		{"resource wildcard", map[string]bool{"person:*": true}, "person:delete", true},
+		{"resource wildcard does not leak across resources", map[string]bool{"person:*": true}, "role:delete", false},
+		{"empty grants", map[string]bool{}, "person:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allows(tt.granted, tt.permission); got != tt.want {
+				t.Errorf("Allows(%v, %q) = %v, want %v", tt.granted, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open("sqlite3", ":memory:")
+
+	if err != nil {
+		t.Fatalf("could not open test db: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	db.AutoMigrate(&Role{}, &RolePermission{}, &UserRole{})
+
+	return db
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	db := openTestDB(t)
+
+	admin := Role{Name: "admin"}
+	db.Create(&admin)
+	db.Create(&RolePermission{RoleID: admin.ID, Permission: "*:*"})
+
+	viewer := Role{Name: "viewer"}
+	db.Create(&viewer)
+	db.Create(&RolePermission{RoleID: viewer.ID, Permission: "person:read"})
+
+	db.Create(&UserRole{UserID: 1, RoleID: admin.ID})
+	db.Create(&UserRole{UserID: 2, RoleID: viewer.ID})
+
+	granted, err := EffectivePermissions(db, 1)
+
+	if err != nil {
+		t.Fatalf("EffectivePermissions(1): unexpected error: %v", err)
+	}
+
+	if !Allows(granted, "anything:goes") {
+		t.Errorf("EffectivePermissions(1) = %v, want it to carry the admin's *:* wildcard", granted)
+	}
+
+	granted, err = EffectivePermissions(db, 2)
+
+	if err != nil {
+		t.Fatalf("EffectivePermissions(2): unexpected error: %v", err)
+	}
+
+	if !Allows(granted, "person:read") || Allows(granted, "person:write") {
+		t.Errorf("EffectivePermissions(2) = %v, want only person:read granted", granted)
+	}
+
+	granted, err = EffectivePermissions(db, 3)
+
+	if err != nil {
+		t.Fatalf("EffectivePermissions(3): unexpected error: %v", err)
+	}
+
+	if len(granted) != 0 {
+		t.Errorf("EffectivePermissions(3) = %v, want no permissions for a user with no role", granted)
+	}
+}
@@ -0,0 +1,137 @@
+package replication
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/middleware"
+)
+
+// Handlers exposes the admin HTTP endpoints for managing replication policies
+type Handlers struct {
+	scheduler *Scheduler
+}
+
+// NewHandlers creates Handlers backed by scheduler
+func NewHandlers(scheduler *Scheduler) *Handlers {
+	return &Handlers{scheduler: scheduler}
+}
+
+// List returns every replication policy
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	var policies []ReplicationPolicy
+
+	db.Find(&policies)
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policies)
+}
+
+// Create adds a new replication policy and schedules it
+func (h *Handlers) Create(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	var policy ReplicationPolicy
+
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := db.Create(&policy).Error; err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.scheduler.Reschedule(policy.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Policy saved but could not be scheduled: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// Update replaces an existing replication policy and reschedules it
+func (h *Handlers) Update(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	id, err := policyIDFromRequest(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var policy ReplicationPolicy
+
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	policy.ID = id
+
+	if err := db.Save(&policy).Error; err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.scheduler.Reschedule(policy.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Policy saved but could not be rescheduled: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// Delete removes a replication policy and cancels its schedule
+func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	db := middleware.DBFromContext(r.Context())
+	id, err := policyIDFromRequest(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := db.Delete(&ReplicationPolicy{}, "id = ?", id).Error; err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.scheduler.Reschedule(id); err != nil {
+		http.Error(w, fmt.Sprintf("Policy deleted but could not be unscheduled: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Trigger runs a policy immediately, outside of its cron schedule
+func (h *Handlers) Trigger(w http.ResponseWriter, r *http.Request) {
+	id, err := policyIDFromRequest(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.scheduler.Trigger(id)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func policyIDFromRequest(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid policy id: %v", err)
+	}
+
+	return uint(id), nil
+}
@@ -0,0 +1,31 @@
+// Package replication copies selected tables from a source tenant to one or
+// more targets on a cron schedule.
+package replication
+
+import "time"
+
+// ReplicationPolicy describes a scheduled copy of selected tables from
+// SourceTenant to TargetRef, which is either another tenant in the same
+// database or a remote Postgres reachable by DSN
+type ReplicationPolicy struct {
+	ID           uint       `json:"id" gorm:"primary_key"`
+	Name         string     `json:"name"`
+	SourceTenant string     `json:"source_tenant"`
+	TargetRef    string     `json:"target_ref"`
+	Tables       string     `json:"tables"` // comma separated, e.g. "person"
+	CronExpr     string     `json:"cron_expr"`
+	Enabled      bool       `json:"enabled"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastStatus   string     `json:"last_status"`
+}
+
+// ReplicationJob records the outcome of a single run of a ReplicationPolicy
+type ReplicationJob struct {
+	ID         uint       `json:"id" gorm:"primary_key"`
+	PolicyID   uint       `json:"policy_id"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	RowsCopied int        `json:"rows_copied"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error"`
+}
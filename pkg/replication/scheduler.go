@@ -0,0 +1,299 @@
+package replication
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/robfig/cron/v3"
+
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/controllers/person"
+	"github.com/cesarkuehl/go-restapi-multitenant/pkg/db"
+)
+
+// maxConcurrentJobs caps how many replication jobs can run at once,
+// regardless of how many policies are scheduled
+const maxConcurrentJobs = 4
+
+// Scheduler parses each enabled ReplicationPolicy's cron expression and
+// dispatches its runs to a bounded worker pool
+type Scheduler struct {
+	pool    *db.TenantPool
+	cron    *cron.Cron
+	workers chan struct{}
+
+	mu      sync.Mutex
+	entries map[uint]cron.EntryID
+}
+
+// NewScheduler migrates the system schema, loads every enabled policy, and
+// starts their cron schedules
+func NewScheduler(pool *db.TenantPool) (*Scheduler, error) {
+	s := &Scheduler{
+		pool:    pool,
+		cron:    cron.New(),
+		workers: make(chan struct{}, maxConcurrentJobs),
+		entries: map[uint]cron.EntryID{},
+	}
+
+	sysDB, err := pool.SystemDB()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sysDB.AutoMigrate(&ReplicationPolicy{}, &ReplicationJob{})
+
+	var policies []ReplicationPolicy
+	sysDB.Where("enabled = ?", true).Find(&policies)
+
+	for _, policy := range policies {
+		if err := s.schedule(policy); err != nil {
+			log.Printf("replication: could not schedule policy %d (%s): %v", policy.ID, policy.Name, err)
+		}
+	}
+
+	s.cron.Start()
+
+	return s, nil
+}
+
+// schedule registers policy's cron expression, replacing any existing entry for it
+func (s *Scheduler) schedule(policy ReplicationPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entryID, ok := s.entries[policy.ID]; ok {
+		s.cron.Remove(entryID)
+		delete(s.entries, policy.ID)
+	}
+
+	if !policy.Enabled {
+		return nil
+	}
+
+	policyID := policy.ID
+
+	entryID, err := s.cron.AddFunc(policy.CronExpr, func() {
+		s.Trigger(policyID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.entries[policy.ID] = entryID
+
+	return nil
+}
+
+// Reschedule re-reads the policy identified by policyID and updates its cron
+// entry, removing it if the policy was deleted. It is called after a policy
+// is created, updated or deleted through the admin endpoints
+func (s *Scheduler) Reschedule(policyID uint) error {
+	sysDB, err := s.pool.SystemDB()
+
+	if err != nil {
+		return err
+	}
+
+	var policy ReplicationPolicy
+	err = sysDB.Where("id = ?", policyID).First(&policy).Error
+
+	if gorm.IsRecordNotFoundError(err) {
+		s.mu.Lock()
+		if entryID, ok := s.entries[policyID]; ok {
+			s.cron.Remove(entryID)
+			delete(s.entries, policyID)
+		}
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.schedule(policy)
+}
+
+// Trigger runs policy's replication job in the worker pool, returning
+// immediately; the job itself runs asynchronously
+func (s *Scheduler) Trigger(policyID uint) {
+	go func() {
+		s.workers <- struct{}{}
+		defer func() { <-s.workers }()
+		s.run(policyID)
+	}()
+}
+
+// run executes a single replication job for policyID, recording its outcome
+// in the ReplicationJob history table
+func (s *Scheduler) run(policyID uint) {
+	sysDB, err := s.pool.SystemDB()
+
+	if err != nil {
+		log.Printf("replication: could not open system schema: %v", err)
+		return
+	}
+
+	var policy ReplicationPolicy
+
+	if err := sysDB.Where("id = ?", policyID).First(&policy).Error; err != nil {
+		log.Printf("replication: policy %d not found: %v", policyID, err)
+		return
+	}
+
+	job := ReplicationJob{PolicyID: policy.ID, StartedAt: time.Now(), Status: "running"}
+	sysDB.Create(&job)
+
+	rows, err := s.replicate(policy)
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.RowsCopied = rows
+
+	if err != nil {
+		job.Status = "failed"
+		job.Error = err.Error()
+		log.Printf("replication: policy %d (%s) failed: %v", policy.ID, policy.Name, err)
+	} else {
+		job.Status = "success"
+	}
+
+	sysDB.Save(&job)
+
+	policy.LastRunAt = &finishedAt
+	policy.LastStatus = job.Status
+	sysDB.Save(&policy)
+}
+
+// replicate opens the source tenant and the target, then copies every
+// configured table in batches with upsert semantics
+func (s *Scheduler) replicate(policy ReplicationPolicy) (int, error) {
+	sourceDB, err := s.pool.For(policy.SourceTenant)
+
+	if err != nil {
+		return 0, fmt.Errorf("could not open source tenant %s: %w", policy.SourceTenant, err)
+	}
+
+	targetDB, closeTarget, err := s.resolveTarget(policy.TargetRef)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer closeTarget()
+
+	rowsCopied := 0
+
+	for _, table := range strings.Split(policy.Tables, ",") {
+		table = strings.TrimSpace(table)
+
+		switch table {
+		case "person":
+			n, err := replicatePeople(sourceDB, targetDB)
+
+			if err != nil {
+				return rowsCopied, fmt.Errorf("replicating %s: %w", table, err)
+			}
+
+			rowsCopied += n
+		default:
+			return rowsCopied, fmt.Errorf("unsupported table %q", table)
+		}
+	}
+
+	return rowsCopied, nil
+}
+
+// resolveTarget opens the replication target: another tenant schema in the
+// same pool, or a standalone connection to a remote DSN
+func (s *Scheduler) resolveTarget(targetRef string) (*gorm.DB, func(), error) {
+	if strings.Contains(targetRef, "host=") || strings.Contains(targetRef, "://") {
+		remote, err := gorm.Open("postgres", targetRef)
+
+		if err != nil {
+			return nil, func() {}, err
+		}
+
+		remote.AutoMigrate(&person.Person{})
+
+		return remote, func() { remote.Close() }, nil
+	}
+
+	tenantDB, err := s.pool.For(targetRef)
+
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("could not open target tenant %s: %w", targetRef, err)
+	}
+
+	return tenantDB, func() {}, nil
+}
+
+// replicatePeople copies every Person row from source to target in batches,
+// upserting by ID so re-running a policy is idempotent
+func replicatePeople(source *gorm.DB, target *gorm.DB) (int, error) {
+	const batchSize = 500
+
+	copied := 0
+	batch := make([]person.Person, 0, batchSize)
+
+	rows, err := source.Model(&person.Person{}).Rows()
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var p person.Person
+
+		if err := source.ScanRows(rows, &p); err != nil {
+			return copied, err
+		}
+
+		batch = append(batch, p)
+
+		if len(batch) == batchSize {
+			if err := upsertPeople(target, batch); err != nil {
+				return copied, err
+			}
+
+			copied += len(batch)
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := upsertPeople(target, batch); err != nil {
+			return copied, err
+		}
+
+		copied += len(batch)
+	}
+
+	return copied, nil
+}
+
+// upsertPeople inserts each person into target, updating the name in place
+// when the ID already exists
+func upsertPeople(target *gorm.DB, people []person.Person) error {
+	for _, p := range people {
+		err := target.Exec(
+			"insert into people (id, name) values (?, ?) on conflict (id) do update set name = excluded.name",
+			p.ID, p.Name,
+		).Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}